@@ -0,0 +1,51 @@
+package kodi_jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Standard JSON-RPC 2.0 error codes, as returned in a response's
+// error.code field. See http://www.jsonrpc.org/specification#error_object.
+//
+// Kodi's JSON-RPC API (see https://kodi.wiki/view/JSON-RPC_API) does not
+// document any codes of its own beyond these five standard ones, so no
+// Kodi-specific constants are defined here.
+const (
+	ErrParseError     = -32700
+	ErrInvalidRequest = -32600
+	ErrMethodNotFound = -32601
+	ErrInvalidParams  = -32602
+	ErrInternalError  = -32603
+)
+
+// Error is a structured JSON-RPC 2.0 error, returned from Read/Call instead
+// of a plain error so callers can switch on Code, e.g.:
+//
+//	var rpcErr *kodi_jsonrpc.Error
+//	if errors.As(err, &rpcErr) && rpcErr.Code == kodi_jsonrpc.ErrMethodNotFound { ... }
+type Error struct {
+	Code    int
+	Message string
+	Data    map[string]interface{}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf(`Kodi error (%d): %s`, e.Code, e.Message)
+}
+
+// errorCode is the wire type of rpcError.Code. The JSON-RPC 2.0 spec
+// mandates an integer, but UnmarshalJSON accepts any JSON number, since some
+// servers (and older Kodi versions) send it as a float.
+type errorCode int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *errorCode) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*c = errorCode(f)
+	return nil
+}
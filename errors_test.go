@@ -0,0 +1,33 @@
+package kodi_jsonrpc
+
+import "testing"
+
+func TestErrorCodeUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		json string
+		want errorCode
+	}{
+		{`-32700`, ErrParseError},
+		{`-32600`, ErrInvalidRequest},
+		// Some servers (and older Kodi versions) send the code as a float.
+		{`-32601.0`, ErrMethodNotFound},
+	}
+
+	for _, c := range cases {
+		var got errorCode
+		if err := got.UnmarshalJSON([]byte(c.json)); err != nil {
+			t.Errorf(`UnmarshalJSON(%s) error = %v`, c.json, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf(`UnmarshalJSON(%s) = %d, want %d`, c.json, got, c.want)
+		}
+	}
+}
+
+func TestErrorCodeUnmarshalJSONInvalid(t *testing.T) {
+	var c errorCode
+	if err := c.UnmarshalJSON([]byte(`"not a number"`)); err == nil {
+		t.Fatal(`UnmarshalJSON(non-number) returned nil error`)
+	}
+}
@@ -0,0 +1,27 @@
+package kodi
+
+import (
+	"context"
+
+	kodi_jsonrpc "github.com/quhar/kodi_jsonrpc"
+)
+
+// ApplicationClient wraps the Kodi `Application.*` namespace.
+type ApplicationClient struct {
+	conn *kodi_jsonrpc.Connection
+}
+
+// ApplicationProperties is the result of Application.GetProperties.
+type ApplicationProperties struct {
+	Volume int  `json:"volume"`
+	Muted  bool `json:"muted"`
+}
+
+// GetProperties fetches the requested Application properties, e.g.
+// []string{"volume", "muted"}.
+func (a *ApplicationClient) GetProperties(ctx context.Context, props []string) (ApplicationProperties, error) {
+	var result ApplicationProperties
+	params := map[string]interface{}{`properties`: props}
+	err := a.conn.Call(ctx, `Application.GetProperties`, params, &result)
+	return result, err
+}
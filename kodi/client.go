@@ -0,0 +1,34 @@
+// Package kodi provides typed, per-namespace bindings on top of
+// kodi_jsonrpc's generic Connection, so callers don't have to hand-build
+// Request{Params: &map[string]interface{}{...}} and hand-unpack
+// map[string]interface{} results for every call.
+//
+// Namespaces are added as they're needed; see player.go, playlist.go and
+// application.go. Eventually these should be generated from Kodi's
+// introspected JSONRPC.Introspect schema rather than hand-written, so new
+// Kodi releases can be re-generated rather than edited by hand.
+package kodi
+
+import (
+	kodi_jsonrpc "github.com/quhar/kodi_jsonrpc"
+)
+
+// Client wraps a kodi_jsonrpc.Connection with typed bindings per Kodi API
+// namespace.
+type Client struct {
+	conn *kodi_jsonrpc.Connection
+
+	Player      *PlayerClient
+	Playlist    *PlaylistClient
+	Application *ApplicationClient
+}
+
+// New wraps an already-connected kodi_jsonrpc.Connection.
+func New(conn *kodi_jsonrpc.Connection) *Client {
+	return &Client{
+		conn:        conn,
+		Player:      &PlayerClient{conn: conn},
+		Playlist:    &PlaylistClient{conn: conn},
+		Application: &ApplicationClient{conn: conn},
+	}
+}
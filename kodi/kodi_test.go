@@ -0,0 +1,272 @@
+package kodi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	kodi_jsonrpc "github.com/quhar/kodi_jsonrpc"
+)
+
+// fakeTransport is an in-memory kodi_jsonrpc.Transport, so the typed
+// bindings below can be exercised against canned responses without a live
+// Kodi server. Each request is answered from results, keyed by method, with
+// the reply queued from Send itself - after sendContext has registered the
+// request's response channel - so there is no race with the reader loop
+// delivering a reply before anyone is listening for it.
+type fakeTransport struct {
+	queue    chan []byte
+	closeOne sync.Once
+
+	mu      sync.Mutex
+	sent    [][]byte
+	results map[string]string // method -> raw JSON "result" body
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		queue: make(chan []byte, 16),
+		results: map[string]string{
+			`JSONRPC.Version`: `{"version":{"major":18,"minor":0,"patch":0}}`,
+		},
+	}
+}
+
+// respond arranges for the next request to method to be answered with
+// result as its JSON-RPC "result" body.
+func (f *fakeTransport) respond(method, result string) {
+	f.mu.Lock()
+	f.results[method] = result
+	f.mu.Unlock()
+}
+
+func (f *fakeTransport) Send(frame []byte) error {
+	f.mu.Lock()
+	f.sent = append(f.sent, frame)
+	var req kodi_jsonrpc.Request
+	_ = json.Unmarshal(frame, &req)
+	result, ok := f.results[req.Method]
+	f.mu.Unlock()
+
+	if ok && req.Id != nil {
+		f.queue <- []byte(fmt.Sprintf(`{"id":%d,"jsonrpc":"2.0","result":%s}`, *req.Id, result))
+	}
+	return nil
+}
+
+// Recv returns an error once the transport is closed, like any real Transport.
+func (f *fakeTransport) Recv() ([]byte, error) {
+	frame, ok := <-f.queue
+	if !ok {
+		return nil, errors.New(`fakeTransport: closed`)
+	}
+	return frame, nil
+}
+
+// Close is idempotent, like net.Conn.Close().
+func (f *fakeTransport) Close() error {
+	f.closeOne.Do(func() { close(f.queue) })
+	return nil
+}
+
+// lastSent returns the most recently sent request, decoded.
+func (f *fakeTransport) lastSent(t *testing.T) kodi_jsonrpc.Request {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sent) == 0 {
+		t.Fatal(`fakeTransport: nothing was sent`)
+	}
+	var req kodi_jsonrpc.Request
+	if err := json.Unmarshal(f.sent[len(f.sent)-1], &req); err != nil {
+		t.Fatalf(`decoding sent request: %v`, err)
+	}
+	return req
+}
+
+// newTestClient brings up a Client backed by a fakeTransport, having already
+// serviced the JSONRPC.Version handshake kodi_jsonrpc.init performs.
+func newTestClient(t *testing.T) (*Client, *fakeTransport) {
+	t.Helper()
+	ft := newFakeTransport()
+
+	conn, err := kodi_jsonrpc.NewWithTransport(ft, 0)
+	if err != nil {
+		t.Fatalf(`NewWithTransport() error = %v`, err)
+	}
+	t.Cleanup(conn.Close)
+
+	return New(conn), ft
+}
+
+func TestPlayerPlayPause(t *testing.T) {
+	client, ft := newTestClient(t)
+
+	ft.respond(`Player.PlayPause`, `{"speed":1}`)
+
+	speed, err := client.Player.PlayPause(context.Background(), 1)
+	if err != nil {
+		t.Fatalf(`PlayPause() error = %v`, err)
+	}
+	if speed != 1 {
+		t.Errorf(`PlayPause() speed = %v, want 1`, speed)
+	}
+
+	req := ft.lastSent(t)
+	if req.Method != `Player.PlayPause` {
+		t.Errorf(`Method = %q, want "Player.PlayPause"`, req.Method)
+	}
+	if req.Params == nil || (*req.Params)[`playerid`] != float64(1) {
+		t.Errorf(`Params = %+v, want {playerid: 1}`, req.Params)
+	}
+}
+
+func TestPlayerStop(t *testing.T) {
+	client, ft := newTestClient(t)
+
+	ft.respond(`Player.Stop`, `"OK"`)
+
+	if err := client.Player.Stop(context.Background(), 2); err != nil {
+		t.Fatalf(`Stop() error = %v`, err)
+	}
+
+	req := ft.lastSent(t)
+	if req.Method != `Player.Stop` {
+		t.Errorf(`Method = %q, want "Player.Stop"`, req.Method)
+	}
+	// Stop must use Call (expects a response/id), not Notify, so Kodi-side
+	// errors are surfaced to the caller instead of silently discarded.
+	if req.Id == nil {
+		t.Error(`Stop() sent a request with no Id - it must use Call, not Notify`)
+	}
+	if req.Params == nil || (*req.Params)[`playerid`] != float64(2) {
+		t.Errorf(`Params = %+v, want {playerid: 2}`, req.Params)
+	}
+}
+
+func TestPlayerGetActivePlayers(t *testing.T) {
+	client, ft := newTestClient(t)
+
+	ft.respond(`Player.GetActivePlayers`, `[{"playerid":1,"type":"video"}]`)
+
+	players, err := client.Player.GetActivePlayers(context.Background())
+	if err != nil {
+		t.Fatalf(`GetActivePlayers() error = %v`, err)
+	}
+	want := []ActivePlayer{{PlayerID: 1, Type: `video`}}
+	if len(players) != 1 || players[0] != want[0] {
+		t.Errorf(`GetActivePlayers() = %+v, want %+v`, players, want)
+	}
+
+	if req := ft.lastSent(t); req.Method != `Player.GetActivePlayers` {
+		t.Errorf(`Method = %q, want "Player.GetActivePlayers"`, req.Method)
+	}
+}
+
+func TestPlaylistGetItems(t *testing.T) {
+	client, ft := newTestClient(t)
+
+	ft.respond(`Playlist.GetItems`, `{"items":[{"type":"movie","label":"Alien","id":1,"file":"alien.mkv"}]}`)
+
+	items, err := client.Playlist.GetItems(context.Background(), 1, []string{`file`})
+	if err != nil {
+		t.Fatalf(`GetItems() error = %v`, err)
+	}
+	want := []PlaylistItem{{Type: `movie`, Label: `Alien`, ID: 1, File: `alien.mkv`}}
+	if len(items) != 1 || items[0] != want[0] {
+		t.Errorf(`GetItems() = %+v, want %+v`, items, want)
+	}
+
+	req := ft.lastSent(t)
+	if req.Method != `Playlist.GetItems` {
+		t.Errorf(`Method = %q, want "Playlist.GetItems"`, req.Method)
+	}
+	if req.Params == nil || (*req.Params)[`playlistid`] != float64(1) {
+		t.Errorf(`Params = %+v, want {playlistid: 1, properties: [file]}`, req.Params)
+	}
+}
+
+func TestApplicationGetProperties(t *testing.T) {
+	client, ft := newTestClient(t)
+
+	ft.respond(`Application.GetProperties`, `{"volume":50,"muted":false}`)
+
+	props, err := client.Application.GetProperties(context.Background(), []string{`volume`, `muted`})
+	if err != nil {
+		t.Fatalf(`GetProperties() error = %v`, err)
+	}
+	want := ApplicationProperties{Volume: 50, Muted: false}
+	if props != want {
+		t.Errorf(`GetProperties() = %+v, want %+v`, props, want)
+	}
+
+	if req := ft.lastSent(t); req.Method != `Application.GetProperties` {
+		t.Errorf(`Method = %q, want "Application.GetProperties"`, req.Method)
+	}
+}
+
+func TestDecodePlayerOnPlay(t *testing.T) {
+	n := kodi_jsonrpc.Notification{
+		Method: `Player.OnPlay`,
+		Data: map[string]interface{}{
+			`item`:   map[string]interface{}{`type`: `movie`, `id`: 1},
+			`player`: map[string]interface{}{`playerid`: 1, `speed`: 1.0},
+		},
+	}
+
+	got, err := DecodePlayerOnPlay(n)
+	if err != nil {
+		t.Fatalf(`DecodePlayerOnPlay() error = %v`, err)
+	}
+	if got.Item.Type != `movie` || got.Item.ID != 1 || got.Player.PlayerID != 1 || got.Player.Speed != 1.0 {
+		t.Errorf(`DecodePlayerOnPlay() = %+v`, got)
+	}
+
+	if _, err := DecodePlayerOnPlay(kodi_jsonrpc.Notification{Method: `Player.OnStop`}); err == nil {
+		t.Error(`DecodePlayerOnPlay() with wrong Method returned nil error`)
+	}
+}
+
+func TestDecodePlayerOnStop(t *testing.T) {
+	n := kodi_jsonrpc.Notification{
+		Method: `Player.OnStop`,
+		Data: map[string]interface{}{
+			`item`: map[string]interface{}{`type`: `movie`, `id`: 1},
+			`end`:  true,
+		},
+	}
+
+	got, err := DecodePlayerOnStop(n)
+	if err != nil {
+		t.Fatalf(`DecodePlayerOnStop() error = %v`, err)
+	}
+	if got.Item.Type != `movie` || got.Item.ID != 1 || !got.End {
+		t.Errorf(`DecodePlayerOnStop() = %+v`, got)
+	}
+
+	if _, err := DecodePlayerOnStop(kodi_jsonrpc.Notification{Method: `Player.OnPlay`}); err == nil {
+		t.Error(`DecodePlayerOnStop() with wrong Method returned nil error`)
+	}
+}
+
+func TestDecodeLibraryOnUpdate(t *testing.T) {
+	n := kodi_jsonrpc.Notification{
+		Method: `Library.OnUpdate`,
+		Data:   map[string]interface{}{`id`: 42, `type`: `movie`},
+	}
+
+	got, err := DecodeLibraryOnUpdate(n)
+	if err != nil {
+		t.Fatalf(`DecodeLibraryOnUpdate() error = %v`, err)
+	}
+	if got.ItemID != 42 || got.ItemType != `movie` {
+		t.Errorf(`DecodeLibraryOnUpdate() = %+v`, got)
+	}
+
+	if _, err := DecodeLibraryOnUpdate(kodi_jsonrpc.Notification{Method: `Player.OnPlay`}); err == nil {
+		t.Error(`DecodeLibraryOnUpdate() with wrong Method returned nil error`)
+	}
+}
@@ -0,0 +1,65 @@
+package kodi
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	kodi_jsonrpc "github.com/quhar/kodi_jsonrpc"
+)
+
+// PlayerOnPlay mirrors the payload of a Player.OnPlay notification.
+type PlayerOnPlay struct {
+	Item struct {
+		Type string `mapstructure:"type"`
+		ID   int    `mapstructure:"id"`
+	} `mapstructure:"item"`
+	Player struct {
+		PlayerID int     `mapstructure:"playerid"`
+		Speed    float64 `mapstructure:"speed"`
+	} `mapstructure:"player"`
+}
+
+// PlayerOnStop mirrors the payload of a Player.OnStop notification.
+type PlayerOnStop struct {
+	Item struct {
+		Type string `mapstructure:"type"`
+		ID   int    `mapstructure:"id"`
+	} `mapstructure:"item"`
+	End bool `mapstructure:"end"`
+}
+
+// LibraryOnUpdate mirrors the payload of a Library.OnUpdate notification.
+type LibraryOnUpdate struct {
+	ItemID   int    `mapstructure:"id"`
+	ItemType string `mapstructure:"type"`
+}
+
+// DecodePlayerOnPlay decodes n's raw data into a PlayerOnPlay. n.Method must
+// be "Player.OnPlay".
+func DecodePlayerOnPlay(n kodi_jsonrpc.Notification) (PlayerOnPlay, error) {
+	var out PlayerOnPlay
+	if n.Method != `Player.OnPlay` {
+		return out, fmt.Errorf(`kodi: expected Player.OnPlay notification, got %s`, n.Method)
+	}
+	return out, mapstructure.Decode(n.Data, &out)
+}
+
+// DecodePlayerOnStop decodes n's raw data into a PlayerOnStop. n.Method must
+// be "Player.OnStop".
+func DecodePlayerOnStop(n kodi_jsonrpc.Notification) (PlayerOnStop, error) {
+	var out PlayerOnStop
+	if n.Method != `Player.OnStop` {
+		return out, fmt.Errorf(`kodi: expected Player.OnStop notification, got %s`, n.Method)
+	}
+	return out, mapstructure.Decode(n.Data, &out)
+}
+
+// DecodeLibraryOnUpdate decodes n's raw data into a LibraryOnUpdate.
+// n.Method must be "Library.OnUpdate".
+func DecodeLibraryOnUpdate(n kodi_jsonrpc.Notification) (LibraryOnUpdate, error) {
+	var out LibraryOnUpdate
+	if n.Method != `Library.OnUpdate` {
+		return out, fmt.Errorf(`kodi: expected Library.OnUpdate notification, got %s`, n.Method)
+	}
+	return out, mapstructure.Decode(n.Data, &out)
+}
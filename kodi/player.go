@@ -0,0 +1,42 @@
+package kodi
+
+import (
+	"context"
+
+	kodi_jsonrpc "github.com/quhar/kodi_jsonrpc"
+)
+
+// PlayerClient wraps the Kodi `Player.*` namespace.
+type PlayerClient struct {
+	conn *kodi_jsonrpc.Connection
+}
+
+// PlayPause toggles play/pause on playerID, returning the resulting playback
+// speed (0 means paused).
+func (p *PlayerClient) PlayPause(ctx context.Context, playerID int) (speed float64, err error) {
+	var result struct {
+		Speed float64 `json:"speed"`
+	}
+	params := map[string]interface{}{`playerid`: playerID}
+	err = p.conn.Call(ctx, `Player.PlayPause`, params, &result)
+	return result.Speed, err
+}
+
+// Stop stops playback on playerID.
+func (p *PlayerClient) Stop(ctx context.Context, playerID int) error {
+	params := map[string]interface{}{`playerid`: playerID}
+	return p.conn.Call(ctx, `Player.Stop`, params, nil)
+}
+
+// GetActivePlayers lists the currently active players.
+func (p *PlayerClient) GetActivePlayers(ctx context.Context) ([]ActivePlayer, error) {
+	var result []ActivePlayer
+	err := p.conn.Call(ctx, `Player.GetActivePlayers`, nil, &result)
+	return result, err
+}
+
+// ActivePlayer describes one entry of Player.GetActivePlayers.
+type ActivePlayer struct {
+	PlayerID int    `json:"playerid"`
+	Type     string `json:"type"`
+}
@@ -0,0 +1,34 @@
+package kodi
+
+import (
+	"context"
+
+	kodi_jsonrpc "github.com/quhar/kodi_jsonrpc"
+)
+
+// PlaylistClient wraps the Kodi `Playlist.*` namespace.
+type PlaylistClient struct {
+	conn *kodi_jsonrpc.Connection
+}
+
+// PlaylistItem is one entry returned by Playlist.GetItems.
+type PlaylistItem struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	ID    int    `json:"id"`
+	File  string `json:"file"`
+}
+
+// GetItems lists the items of playlistID. props selects which additional
+// fields Kodi should include (may be nil for the defaults).
+func (p *PlaylistClient) GetItems(ctx context.Context, playlistID int, props []string) ([]PlaylistItem, error) {
+	var result struct {
+		Items []PlaylistItem `json:"items"`
+	}
+	params := map[string]interface{}{`playlistid`: playlistID}
+	if len(props) > 0 {
+		params[`properties`] = props
+	}
+	err := p.conn.Call(ctx, `Playlist.GetItems`, params, &result)
+	return result.Items, err
+}
@@ -7,11 +7,11 @@
 package kodi_jsonrpc
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net"
 	"sync"
 	"time"
 
@@ -21,14 +21,23 @@ import (
 
 // Connection is the main type for interacting with Kodi
 type Connection struct {
-	conn             net.Conn
-	write            chan interface{}
-	Notifications    chan Notification
-	enc              *json.Encoder
-	dec              *json.Decoder
+	transport Transport
+	write     chan interface{}
+
+	// Notifications is a single fan-in of every notification, buffered 16
+	// deep, dropping the oldest entry if a consumer falls behind.
+	//
+	// Deprecated: use Subscribe instead, which queues per method (or
+	// wildcard) without ever dropping a notification.
+	Notifications chan Notification
+
+	subscriptionLock sync.Mutex
+	subscriptions    map[string][]*Subscription
+
 	responseLock     sync.Mutex
 	connectedLock    sync.Mutex
 	connectLock      sync.Mutex
+	closeOnce        sync.Once
 	writeWait        sync.WaitGroup
 	notificationWait sync.WaitGroup
 	requestID        uint32
@@ -50,7 +59,7 @@ type Request struct {
 }
 
 type rpcError struct {
-	Code    float64                 `json:"code"`
+	Code    errorCode               `json:"code"`
 	Message string                  `json:"message"`
 	Data    *map[string]interface{} `json:"data"`
 }
@@ -60,6 +69,11 @@ type Response struct {
 	channel  *chan *rpcResponse
 	Pending  bool // If Pending is false, Response is unwanted, or been consumed
 	readLock sync.Mutex
+
+	// id and conn are only set when the Response was registered with a
+	// Connection, so Call can remove it from conn.responses on cancellation.
+	id   uint32
+	conn *Connection
 }
 
 type rpcResponse struct {
@@ -67,8 +81,11 @@ type rpcResponse struct {
 	JsonRPC string                  `json:"jsonrpc"`
 	Method  *string                 `json:"method"`
 	Params  *map[string]interface{} `json:"params"`
-	Result  *map[string]interface{} `json:"result"`
-	Error   *rpcError               `json:"error"`
+	// Result is kept as the raw, undecoded JSON value rather than
+	// map[string]interface{}, since some methods (e.g.
+	// Player.GetActivePlayers) return a bare JSON array, not an object.
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
 }
 
 // Notification stores Kodi server->client notifications.
@@ -81,6 +98,11 @@ type Notification struct {
 			} `json:"item" mapstructure:"item"` // Optional
 		} `json:"data" mapstructure:"data"`
 	} `json:"params" mapstructure:"params"`
+
+	// Data holds the undecoded `params.data` object for this notification.
+	// Typed bindings (see the kodi subpackage) use it to decode fields this
+	// struct doesn't expose, such as Player.Speed or Item.Id.
+	Data map[string]interface{} `json:"-" mapstructure:"-"`
 }
 
 const (
@@ -107,18 +129,40 @@ func init() {
 }
 
 // New returns a Connection to the specified address.
+// address may be a bare `host:port` (the raw TCP socket, as before), or a
+// `tcp://`, `ws://` or `wss://` URL to select the transport explicitly.
 // If timeout (seconds) is greater than zero, connection will fail if initial
 // connection is not established within this time.
 //
+// New returns a *Connection, not a Connection, because the reader/writer
+// goroutines it starts hold a pointer to it: handing back a copy would let
+// that copy's state (Closed, the sync primitives, ...) drift out of sync
+// with the instance the goroutines actually see.
+//
 // User must ensure Close() is called on returned Connection when finished with
 // it, to avoid leaks.
-func New(address string, timeout time.Duration) (conn Connection, err error) {
-	conn = Connection{}
+func New(address string, timeout time.Duration) (conn *Connection, err error) {
+	conn = &Connection{}
 	err = conn.init(address, timeout)
 
 	return conn, err
 }
 
+// NewWithTransport returns a Connection using an already-dialled Transport,
+// for callers that want to construct the TCP or WebSocket transport (or a
+// custom one) themselves rather than have New pick one from the address.
+//
+// See New for why this returns a *Connection rather than a Connection.
+//
+// User must ensure Close() is called on returned Connection when finished with
+// it, to avoid leaks.
+func NewWithTransport(transport Transport, timeout time.Duration) (conn *Connection, err error) {
+	conn = &Connection{transport: transport}
+	err = conn.init(``, timeout)
+
+	return conn, err
+}
+
 // SetLogLevel adjusts the level of logger output, level must be one of:
 //
 // LogDebugLevel
@@ -162,17 +206,125 @@ func (rchan *Response) Read(timeout time.Duration) (result map[string]interface{
 	if res == nil {
 		return result, errors.New(`Empty result received`)
 	}
-	result, err = res.unpack()
+	raw, err := res.unpack()
+	if err != nil || raw == nil {
+		return result, err
+	}
+	err = json.Unmarshal(raw, &result)
 
 	return result, err
 }
 
+// readContext waits for the response honoring ctx, like Read, but cancelling
+// ctx unblocks the caller immediately instead of waiting out a fixed timeout.
+// On cancellation, the pending entry is removed from the connection's
+// response table so the reader goroutine's eventual dispatch doesn't block
+// and the entry isn't mistaken for an unanswered request forever.
+func (rchan *Response) readContext(ctx context.Context) (result json.RawMessage, err error) {
+	rchan.readLock.Lock()
+	defer rchan.readLock.Unlock()
+	defer func() {
+		rchan.Pending = false
+	}()
+
+	if rchan.Pending != true {
+		return result, errors.New(`No pending responses!`)
+	}
+	if rchan.channel == nil {
+		return result, errors.New(`Expected response channel, but got nil!`)
+	}
+
+	select {
+	case res := <-*rchan.channel:
+		close(*rchan.channel)
+		if res == nil {
+			return result, errors.New(`Empty result received`)
+		}
+		return res.unpack()
+	case <-ctx.Done():
+		if rchan.conn != nil {
+			rchan.conn.responseLock.Lock()
+			delete(rchan.conn.responses, rchan.id)
+			rchan.conn.responseLock.Unlock()
+		}
+		return result, ctx.Err()
+	}
+}
+
+// newRequest builds a Request for method, marshalling params (a struct, map,
+// or nil) into the JSON object Kodi's JSON-RPC API expects.
+func newRequest(method string, params interface{}) (req Request, err error) {
+	req = Request{Method: method}
+	if params == nil {
+		return req, nil
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return req, fmt.Errorf(`kodi_jsonrpc: marshalling params for %s: %v`, method, err)
+	}
+
+	m := make(map[string]interface{})
+	if err = json.Unmarshal(raw, &m); err != nil {
+		return req, fmt.Errorf(`kodi_jsonrpc: params for %s must encode to a JSON object: %v`, method, err)
+	}
+	req.Params = &m
+
+	return req, nil
+}
+
+// Call sends method with params and decodes the response into result (which
+// should be a pointer, or nil to discard it), honoring ctx for cancellation
+// and deadlines. Unlike Send/Read, cancelling ctx unblocks Call immediately
+// rather than leaking a goroutine waiting out Read's timeout.
+func (c *Connection) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	req, err := newRequest(method, params)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.sendContext(ctx, req, true)
+	if err != nil {
+		return err
+	}
+
+	raw, err := res.readContext(ctx)
+	if err != nil {
+		return err
+	}
+	if result == nil || raw == nil {
+		return nil
+	}
+
+	return json.Unmarshal(raw, result)
+}
+
+// Notify sends a fire-and-forget request that does not expect a response,
+// honoring ctx for cancellation.
+func (c *Connection) Notify(ctx context.Context, method string, params interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	req, err := newRequest(method, params)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.sendContext(ctx, req, false)
+	return err
+}
+
 // Unpack the result and any errors from the Response
-func (res *rpcResponse) unpack() (result map[string]interface{}, err error) {
+func (res *rpcResponse) unpack() (result json.RawMessage, err error) {
 	if res.Error != nil {
-		err = fmt.Errorf(`Kodi error (%v): %v`, res.Error.Code, res.Error.Message)
+		rpcErr := &Error{Code: int(res.Error.Code), Message: res.Error.Message}
+		if res.Error.Data != nil {
+			rpcErr.Data = *res.Error.Data
+		}
+		err = rpcErr
 	} else if res.Result != nil {
-		result = *res.Result
+		result = res.Result
 	} else {
 		log.WithField(`response`, res).Debug(`Received unknown response type from Kodi`)
 	}
@@ -189,12 +341,16 @@ func (c *Connection) init(address string, timeout time.Duration) (err error) {
 		c.timeout = timeout
 	}
 
-	if err = c.connect(); err != nil {
+	if c.transport != nil {
+		// Transport was already dialled by the caller (NewWithTransport).
+		c.connected(true)
+	} else if err = c.connect(); err != nil {
 		return err
 	}
 
 	c.write = make(chan interface{}, 16)
 	c.Notifications = make(chan Notification, 16)
+	c.subscriptions = make(map[string][]*Subscription)
 
 	c.responses = make(map[uint32]*chan *rpcResponse)
 
@@ -226,6 +382,14 @@ func (c *Connection) init(address string, timeout time.Duration) (err error) {
 // false (for fire-and-forget commands that don't return any useful response).
 // Returns error on closed connection
 func (c *Connection) Send(req Request, wantResponse bool) (res Response, err error) {
+	return c.sendContext(context.Background(), req, wantResponse)
+}
+
+// sendContext is Send, but honoring ctx while enqueuing the request onto
+// c.write. Without this, a Call/Notify whose ctx has a deadline could still
+// block past it forever if c.write's buffer is full (e.g. the writer
+// goroutine is stuck retrying connect()).
+func (c *Connection) sendContext(ctx context.Context, req Request, wantResponse bool) (res Response, err error) {
 	if c.Closed {
 		return res, errors.New(`Cannot send on closed connection`)
 	}
@@ -233,29 +397,86 @@ func (c *Connection) Send(req Request, wantResponse bool) (res Response, err err
 	res = Response{}
 
 	c.writeWait.Add(1)
+	defer c.writeWait.Done()
+
 	if wantResponse == true {
 		c.responseLock.Lock()
 		id := c.requestID
-		ch := make(chan *rpcResponse)
+		// Buffered so the reader goroutine's dispatch never blocks, even if
+		// a context-aware caller has stopped waiting (see Call/readContext).
+		ch := make(chan *rpcResponse, 1)
 		c.responses[id] = &ch
 		c.requestID++
 		c.responseLock.Unlock()
 		req.Id = &id
 
 		log.WithField(`request`, req).Debug(`Sending Kodi Request (response desired)`)
-		c.write <- req
+		select {
+		case c.write <- req:
+		case <-ctx.Done():
+			c.responseLock.Lock()
+			delete(c.responses, id)
+			c.responseLock.Unlock()
+			return res, ctx.Err()
+		}
 		res.channel = &ch
 		res.Pending = true
+		res.id = id
+		res.conn = c
 	} else {
 		log.WithField(`request`, req).Debug(`Sending Kodi Request (response undesired)`)
-		c.write <- req
+		select {
+		case c.write <- req:
+		case <-ctx.Done():
+			return res, ctx.Err()
+		}
 		res.Pending = false
 	}
-	c.writeWait.Done()
 
 	return
 }
 
+// SendBatch sends reqs as a single JSON-RPC 2.0 batch request (a JSON array
+// of Request objects, per the spec), and returns one Response per entry in
+// reqs, in the same order. Does not attach response channels if wantResponse
+// is false. Kodi replies with a single JSON array of Response objects,
+// correlated back to these by id; see reader/dispatch.
+// Returns error on closed connection.
+func (c *Connection) SendBatch(reqs []Request, wantResponse bool) (responses []Response, err error) {
+	if c.Closed {
+		return nil, errors.New(`Cannot send on closed connection`)
+	}
+	if len(reqs) == 0 {
+		return nil, errors.New(`SendBatch requires at least one request`)
+	}
+
+	responses = make([]Response, len(reqs))
+
+	c.writeWait.Add(1)
+	c.responseLock.Lock()
+	for i := range reqs {
+		reqs[i].JsonRPC = `2.0`
+		if wantResponse == true {
+			id := c.requestID
+			ch := make(chan *rpcResponse, 1)
+			c.responses[id] = &ch
+			c.requestID++
+			reqs[i].Id = &id
+
+			responses[i] = Response{channel: &ch, Pending: true, id: id, conn: c}
+		} else {
+			responses[i] = Response{Pending: false}
+		}
+	}
+	c.responseLock.Unlock()
+
+	log.WithField(`requests`, reqs).Debug(`Sending Kodi batch request`)
+	c.write <- reqs
+	c.writeWait.Done()
+
+	return responses, nil
+}
+
 // connected sets whether we're currently connected or not
 func (c *Connection) connected(status bool) {
 	c.connectedLock.Lock()
@@ -263,7 +484,13 @@ func (c *Connection) connected(status bool) {
 	c.Connected = status
 }
 
-// connect establishes a TCP connection
+// errNoRedialAddress is returned by connect when the Connection was built via
+// NewWithTransport with no address, so a lost transport can never be
+// redialled. It is permanent: reader and writer must stop retrying and shut
+// the Connection down instead of spinning on it forever.
+var errNoRedialAddress = errors.New(`kodi_jsonrpc: cannot reconnect a Connection with no address to redial`)
+
+// connect establishes the underlying transport
 func (c *Connection) connect() (err error) {
 	c.connected(false)
 	c.connectLock.Lock()
@@ -275,11 +502,16 @@ func (c *Connection) connect() (err error) {
 		return
 	}
 
-	if c.conn != nil {
-		_ = c.conn.Close()
+	if c.transport != nil {
+		_ = c.transport.Close()
+		c.transport = nil
 	}
 
-	c.conn, err = net.Dial(`tcp`, c.address)
+	if c.address == `` {
+		return errNoRedialAddress
+	}
+
+	c.transport, err = DialTransport(c.address)
 	if err != nil {
 		success := make(chan bool, 1)
 		done := make(chan bool, 1)
@@ -288,7 +520,7 @@ func (c *Connection) connect() (err error) {
 				log.WithField(`error`, err).Error(`Connecting to Kodi`)
 				log.Info(`Attempting reconnect...`)
 				time.Sleep(time.Second)
-				c.conn, err = net.Dial(`tcp`, c.address)
+				c.transport, err = DialTransport(c.address)
 				select {
 				case <-done:
 					break
@@ -310,9 +542,6 @@ func (c *Connection) connect() (err error) {
 		}
 	}
 
-	c.enc = json.NewEncoder(c.conn)
-	c.dec = json.NewDecoder(c.conn)
-
 	log.Info(`Connected to Kodi`)
 	c.connected(true)
 
@@ -328,12 +557,22 @@ func (c *Connection) writer() {
 		if req == nil {
 			return
 		}
-		for err := c.enc.Encode(req); err != nil; {
-			log.WithField(`error`, err).Warn(`Failed encoding request for Kodi`)
+		frame, err := json.Marshal(req)
+		if err != nil {
+			log.WithField(`error`, err).Error(`Failed marshalling request for Kodi`)
+			continue
+		}
+		for err = c.transport.Send(frame); err != nil; {
+			log.WithField(`error`, err).Warn(`Failed sending request to Kodi`)
 			if err = c.connect(); err != nil {
+				if errors.Is(err, errNoRedialAddress) {
+					log.WithField(`error`, err).Error(`Cannot redial Kodi; closing connection`)
+					c.Close()
+					return
+				}
 				continue
 			}
-			err = c.enc.Encode(req)
+			err = c.transport.Send(frame)
 		}
 	}
 }
@@ -341,11 +580,10 @@ func (c *Connection) writer() {
 // reader loop processes inbound responses and notifications
 func (c *Connection) reader() {
 	for {
-		res := new(rpcResponse)
-		err := c.dec.Decode(res)
-		if _, ok := err.(net.Error); err == io.EOF || ok {
-			// If we got error while reading from codi and status is not connected
-			// return from goroutine as our client has been closed
+		frame, err := c.transport.Recv()
+		if err != nil {
+			// If we got error while reading from Kodi and the Connection has
+			// been closed, return from goroutine as our client is done
 			if c.Closed {
 				return
 			}
@@ -353,71 +591,132 @@ func (c *Connection) reader() {
 			log.Error(`If this error persists, make sure you are using the JSON-RPC port, not the HTTP port!`)
 			for err != nil {
 				err = c.connect()
+				if errors.Is(err, errNoRedialAddress) {
+					log.WithField(`error`, err).Error(`Cannot redial Kodi; closing connection`)
+					c.Close()
+					return
+				}
 			}
-		} else if err != nil {
-			log.WithField(`error`, err).Error(`Decoding response from Kodi`)
 			continue
 		}
-		if res.Id == nil && res.Method != nil {
-			c.notificationWait.Add(1)
-			// Process notifications in a separate routine so we don't delay the
-			// processing of standard responses.  This does mean losing ordering
-			// guarantees for notifications.
-			go func() {
-				if res.Params != nil {
-					log.WithFields(log.Fields{
-						`notification.Method`: *res.Method,
-						`notification.Params`: *res.Params,
-					}).Debug(`Received notification from Kodi`)
-				} else {
-					log.WithField(`notification.Method`, *res.Method).Debug(`Received notification from Kodi`)
-				}
-				n := Notification{}
-				n.Method = *res.Method
-				err := mapstructure.Decode(res.Params, &n.Params)
-				if err != nil {
-					log.WithField(`notification.Method`, *res.Method).Warn(`Decoding notifcation failed`)
-					return
-				}
-				// Implement notification writes as a ring buffer.
-				// In case the client is not processing notifications, we don't
-				// want to block indefinitely here, instead drop the oldest
-				// notification after 200ms, and log a warning
-				select {
-				case c.Notifications <- n:
-				case <-time.After(200 * time.Millisecond):
-					<-c.Notifications
-					c.Notifications <- n
-					log.Warn(`Dropped oldest notification, buffer full`)
-				}
-				c.notificationWait.Done()
-			}()
-		} else if res.Id != nil {
-			if ch := c.responses[uint32(*res.Id)]; ch != nil {
-				if res.Result != nil {
-					log.WithField(`response.Result`, *res.Result).Debug(`Received response from Kodi`)
-				}
-				*ch <- res
-			} else {
-				log.WithField(`response.Id`, *res.Id).Warn(`Received Kodi response for unknown request`)
-				log.WithField(`connection.responses`, c.responses).Debug(`Current response channels`)
+		c.dispatch(frame)
+	}
+}
+
+// dispatch decodes a single inbound frame and routes it to handleResponse.
+// Per the JSON-RPC 2.0 spec, a batch request (SendBatch) gets back a single
+// JSON array of Response objects rather than one at a time, so the frame is
+// peeked for a leading '[' and, if found, each array element is decoded and
+// routed individually.
+func (c *Connection) dispatch(frame []byte) {
+	dec := json.NewDecoder(bytes.NewReader(frame))
+	tok, err := dec.Token()
+	if err != nil {
+		log.WithField(`error`, err).Error(`Decoding response from Kodi`)
+		return
+	}
+
+	if delim, ok := tok.(json.Delim); ok && delim == '[' {
+		for dec.More() {
+			res := new(rpcResponse)
+			if err := dec.Decode(res); err != nil {
+				log.WithField(`error`, err).Error(`Decoding batched response from Kodi`)
+				continue
 			}
-		} else {
-			if res.Error != nil {
-				log.WithField(`response.Error`, *res.Error).Warn(`Received unparseable Kodi response`)
+			c.handleResponse(res)
+		}
+		return
+	}
+
+	res := new(rpcResponse)
+	if err := json.Unmarshal(frame, res); err != nil {
+		log.WithField(`error`, err).Error(`Decoding response from Kodi`)
+		return
+	}
+	c.handleResponse(res)
+}
+
+// handleResponse routes a single decoded response/notification to its
+// waiting channel, or to the Notifications channel.
+func (c *Connection) handleResponse(res *rpcResponse) {
+	if res.Id == nil && res.Method != nil {
+		c.notificationWait.Add(1)
+		// Process notifications in a separate routine so we don't delay the
+		// processing of standard responses.  This does mean losing ordering
+		// guarantees for notifications.
+		go func() {
+			if res.Params != nil {
+				log.WithFields(log.Fields{
+					`notification.Method`: *res.Method,
+					`notification.Params`: *res.Params,
+				}).Debug(`Received notification from Kodi`)
 			} else {
-				log.WithField(`response`, res).Warn(`Received unparseable Kodi response`)
+				log.WithField(`notification.Method`, *res.Method).Debug(`Received notification from Kodi`)
 			}
+			n := Notification{}
+			n.Method = *res.Method
+			err := mapstructure.Decode(res.Params, &n.Params)
+			if err != nil {
+				log.WithField(`notification.Method`, *res.Method).Warn(`Decoding notifcation failed`)
+				return
+			}
+			if res.Params != nil {
+				if data, ok := (*res.Params)[`data`].(map[string]interface{}); ok {
+					n.Data = data
+				}
+			}
+			c.dispatchSubscriptions(n)
+			// Implement notification writes as a ring buffer.
+			// In case the client is not processing notifications, we don't
+			// want to block indefinitely here, instead drop the oldest
+			// notification after 200ms, and log a warning
+			select {
+			case c.Notifications <- n:
+			case <-time.After(200 * time.Millisecond):
+				<-c.Notifications
+				c.Notifications <- n
+				log.Warn(`Dropped oldest notification, buffer full`)
+			}
+			c.notificationWait.Done()
+		}()
+	} else if res.Id != nil {
+		// c.responses is also written from Send/SendBatch (registering) and
+		// readContext (deleting on cancellation), all under responseLock, so
+		// this read needs the same lock to avoid racing with them.
+		c.responseLock.Lock()
+		ch := c.responses[uint32(*res.Id)]
+		c.responseLock.Unlock()
+		if ch != nil {
+			if res.Result != nil {
+				log.WithField(`response.Result`, string(res.Result)).Debug(`Received response from Kodi`)
+			}
+			*ch <- res
+		} else {
+			log.WithField(`response.Id`, *res.Id).Warn(`Received Kodi response for unknown request`)
+			c.responseLock.Lock()
+			log.WithField(`connection.responses`, c.responses).Debug(`Current response channels`)
+			c.responseLock.Unlock()
+		}
+	} else {
+		if res.Error != nil {
+			log.WithField(`response.Error`, *res.Error).Warn(`Received unparseable Kodi response`)
+		} else {
+			log.WithField(`response`, res).Warn(`Received unparseable Kodi response`)
 		}
 	}
 }
 
 // Close closes the Kodi connection and associated channels
 // Subsequent Sends will return an error for closed connections
+//
+// Close is safe to call more than once, and concurrently with itself: the
+// reader/writer goroutines close a dead Connection on their own (e.g. when
+// they can't redial), and that can race an explicit external Close.
 func (c *Connection) Close() {
-	if c.Closed {
-		return
-	}
+	c.closeOnce.Do(c.close)
+}
+
+func (c *Connection) close() {
 	c.Closed = true
 
 	if c.write != nil {
@@ -428,8 +727,21 @@ func (c *Connection) Close() {
 		c.notificationWait.Wait()
 		close(c.Notifications)
 	}
-	if c.conn != nil {
-		_ = c.conn.Close()
+	if c.transport != nil {
+		_ = c.transport.Close()
+	}
+
+	// Unsubscribe every live Subscription, so a consumer blocked in
+	// sub.Recv(context.Background()) - the pattern Subscribe is meant to
+	// support - is woken up with an error instead of leaking forever.
+	c.subscriptionLock.Lock()
+	var subs []*Subscription
+	for _, methodSubs := range c.subscriptions {
+		subs = append(subs, methodSubs...)
+	}
+	c.subscriptionLock.Unlock()
+	for _, sub := range subs {
+		sub.Unsubscribe()
 	}
 
 	log.Info(`Disconnected from Kodi`)
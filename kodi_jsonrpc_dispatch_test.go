@@ -0,0 +1,81 @@
+package kodi_jsonrpc
+
+import (
+	"testing"
+)
+
+func newTestConnection() *Connection {
+	return &Connection{
+		Notifications: make(chan Notification, 16),
+		subscriptions: make(map[string][]*Subscription),
+		responses:     make(map[uint32]*chan *rpcResponse),
+	}
+}
+
+func registerResponse(c *Connection, id uint32) chan *rpcResponse {
+	ch := make(chan *rpcResponse, 1)
+	c.responseLock.Lock()
+	c.responses[id] = &ch
+	c.responseLock.Unlock()
+	return ch
+}
+
+func TestDispatchSingleResponse(t *testing.T) {
+	c := newTestConnection()
+	ch := registerResponse(c, 1)
+
+	c.dispatch([]byte(`{"id":1,"jsonrpc":"2.0","result":{"ok":true}}`))
+
+	select {
+	case res := <-ch:
+		if string(res.Result) != `{"ok":true}` {
+			t.Errorf(`Result = %s, want {"ok":true}`, res.Result)
+		}
+	default:
+		t.Fatal(`dispatch did not deliver a response`)
+	}
+}
+
+func TestDispatchBatchResponse(t *testing.T) {
+	c := newTestConnection()
+	ch1 := registerResponse(c, 1)
+	ch2 := registerResponse(c, 2)
+
+	c.dispatch([]byte(`[{"id":1,"jsonrpc":"2.0","result":1},{"id":2,"jsonrpc":"2.0","result":2}]`))
+
+	for id, ch := range map[uint32]chan *rpcResponse{1: ch1, 2: ch2} {
+		select {
+		case res := <-ch:
+			if int(*res.Id) != int(id) {
+				t.Errorf(`got response id %v, want %d`, *res.Id, id)
+			}
+		default:
+			t.Fatalf(`dispatch did not deliver response %d`, id)
+		}
+	}
+}
+
+func TestDispatchArrayResult(t *testing.T) {
+	c := newTestConnection()
+	ch := registerResponse(c, 1)
+
+	// A bare JSON array result (e.g. Player.GetActivePlayers) must not be
+	// mistaken for a batch: only a top-level array is a batch, and here the
+	// array is nested inside the "result" field of a single response.
+	c.dispatch([]byte(`{"id":1,"jsonrpc":"2.0","result":[{"playerid":1,"type":"video"}]}`))
+
+	select {
+	case res := <-ch:
+		if string(res.Result) != `[{"playerid":1,"type":"video"}]` {
+			t.Errorf(`Result = %s, want [{"playerid":1,"type":"video"}]`, res.Result)
+		}
+	default:
+		t.Fatal(`dispatch did not deliver a response`)
+	}
+}
+
+func TestDispatchInvalidFrame(t *testing.T) {
+	c := newTestConnection()
+	// Malformed frames are logged and dropped, not panicked on.
+	c.dispatch([]byte(`not json`))
+}
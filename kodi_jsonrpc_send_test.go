@@ -0,0 +1,51 @@
+package kodi_jsonrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newBlockedConnection returns a Connection whose write channel is already
+// full, so any further sendContext call blocks on `c.write <- req` until its
+// ctx is done - exactly the "writer goroutine stuck, buffer full" scenario
+// Call/Notify need to escape from.
+func newBlockedConnection() *Connection {
+	c := &Connection{
+		write:     make(chan interface{}, 1),
+		responses: make(map[uint32]*chan *rpcResponse),
+	}
+	c.write <- struct{}{}
+	return c
+}
+
+func TestSendContextCancelWhileBlocked(t *testing.T) {
+	c := newBlockedConnection()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.sendContext(ctx, Request{Method: `JSONRPC.Version`}, true)
+	if err != ctx.Err() {
+		t.Errorf(`sendContext() error = %v, want %v`, err, ctx.Err())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf(`sendContext() took %v, want it to return as soon as ctx is done`, elapsed)
+	}
+
+	if len(c.responses) != 0 {
+		t.Errorf(`responses has %d entries after cancelled sendContext, want 0`, len(c.responses))
+	}
+}
+
+func TestSendContextCancelWhileBlockedNoResponse(t *testing.T) {
+	c := newBlockedConnection()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.sendContext(ctx, Request{Method: `Player.Stop`}, false); err != ctx.Err() {
+		t.Errorf(`sendContext() error = %v, want %v`, err, ctx.Err())
+	}
+}
@@ -0,0 +1,87 @@
+package kodi_jsonrpc
+
+import "testing"
+
+func TestSendBatch(t *testing.T) {
+	c := &Connection{
+		write:     make(chan interface{}, 1),
+		responses: make(map[uint32]*chan *rpcResponse),
+	}
+
+	reqs := []Request{{Method: `Player.GetActivePlayers`}, {Method: `Application.GetProperties`}}
+	responses, err := c.SendBatch(reqs, true)
+	if err != nil {
+		t.Fatalf(`SendBatch() error = %v`, err)
+	}
+	if len(responses) != len(reqs) {
+		t.Fatalf(`SendBatch() returned %d responses, want %d`, len(responses), len(reqs))
+	}
+
+	sent, ok := (<-c.write).([]Request)
+	if !ok {
+		t.Fatal(`SendBatch() did not write the batch to c.write`)
+	}
+	if len(sent) != len(reqs) {
+		t.Fatalf(`wrote %d requests, want %d`, len(sent), len(reqs))
+	}
+
+	seen := make(map[uint32]bool)
+	for i, req := range sent {
+		if req.JsonRPC != `2.0` {
+			t.Errorf(`request %d JsonRPC = %q, want "2.0"`, i, req.JsonRPC)
+		}
+		if req.Id == nil {
+			t.Fatalf(`request %d has no Id assigned`, i)
+		}
+		if seen[uint32(*req.Id)] {
+			t.Errorf(`request %d reused id %d`, i, *req.Id)
+		}
+		seen[uint32(*req.Id)] = true
+
+		if !responses[i].Pending {
+			t.Errorf(`responses[%d].Pending = false, want true`, i)
+		}
+		if responses[i].id != uint32(*req.Id) {
+			t.Errorf(`responses[%d].id = %d, want %d`, i, responses[i].id, *req.Id)
+		}
+
+		c.responseLock.Lock()
+		_, registered := c.responses[uint32(*req.Id)]
+		c.responseLock.Unlock()
+		if !registered {
+			t.Errorf(`request id %d not registered in c.responses`, *req.Id)
+		}
+	}
+}
+
+func TestSendBatchNoResponse(t *testing.T) {
+	c := &Connection{
+		write:     make(chan interface{}, 1),
+		responses: make(map[uint32]*chan *rpcResponse),
+	}
+
+	responses, err := c.SendBatch([]Request{{Method: `Player.Stop`}}, false)
+	if err != nil {
+		t.Fatalf(`SendBatch() error = %v`, err)
+	}
+	if responses[0].Pending {
+		t.Error(`responses[0].Pending = true, want false for a fire-and-forget batch`)
+	}
+	if len(c.responses) != 0 {
+		t.Errorf(`c.responses has %d entries, want 0 for a fire-and-forget batch`, len(c.responses))
+	}
+}
+
+func TestSendBatchEmpty(t *testing.T) {
+	c := &Connection{write: make(chan interface{}, 1)}
+	if _, err := c.SendBatch(nil, true); err == nil {
+		t.Fatal(`SendBatch(nil, ...) returned nil error`)
+	}
+}
+
+func TestSendBatchClosed(t *testing.T) {
+	c := &Connection{Closed: true}
+	if _, err := c.SendBatch([]Request{{Method: `JSONRPC.Version`}}, true); err == nil {
+		t.Fatal(`SendBatch() on a closed connection returned nil error`)
+	}
+}
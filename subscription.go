@@ -0,0 +1,139 @@
+package kodi_jsonrpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Subscription is a per-method (or wildcard) queue of Notifications, created
+// by Connection.Subscribe. Unlike the legacy Notifications channel, its
+// queue grows to hold whatever the consumer hasn't read yet instead of
+// dropping events, so a slow consumer sees every notification, just late.
+type Subscription struct {
+	pattern string
+	conn    *Connection
+
+	mu     sync.Mutex
+	queue  []Notification
+	closed bool
+	signal chan struct{}
+}
+
+// Subscribe registers a new Subscription for method, which may be an exact
+// Kodi notification method (`Player.OnPlay`), a namespace wildcard
+// (`Player.*`), or `*` for every notification. Call Unsubscribe when done
+// with it to stop it from growing.
+func (c *Connection) Subscribe(method string) *Subscription {
+	sub := &Subscription{
+		pattern: method,
+		conn:    c,
+		signal:  make(chan struct{}, 1),
+	}
+
+	c.subscriptionLock.Lock()
+	c.subscriptions[method] = append(c.subscriptions[method], sub)
+	c.subscriptionLock.Unlock()
+
+	return sub
+}
+
+// dispatchSubscriptions enqueues n onto every Subscription whose pattern
+// matches n.Method. Called from the reader's notification goroutine, so it
+// must never block.
+func (c *Connection) dispatchSubscriptions(n Notification) {
+	c.subscriptionLock.Lock()
+	defer c.subscriptionLock.Unlock()
+
+	for pattern, subs := range c.subscriptions {
+		if !subscriptionMatches(pattern, n.Method) {
+			continue
+		}
+		for _, sub := range subs {
+			sub.enqueue(n)
+		}
+	}
+}
+
+// subscriptionMatches reports whether pattern (as passed to Subscribe)
+// matches method. `*` matches everything, `Namespace.*` matches any method
+// in that namespace, anything else must match exactly.
+func subscriptionMatches(pattern, method string) bool {
+	if pattern == `*` {
+		return true
+	}
+	if strings.HasSuffix(pattern, `.*`) {
+		return strings.HasPrefix(method, pattern[:len(pattern)-1])
+	}
+	return pattern == method
+}
+
+// enqueue appends n to the queue without blocking, and wakes up a pending
+// Recv if there is one.
+func (s *Subscription) enqueue(n Notification) {
+	s.mu.Lock()
+	s.queue = append(s.queue, n)
+	s.mu.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Recv blocks until a Notification is available, ctx is cancelled, or the
+// Subscription is closed via Unsubscribe.
+func (s *Subscription) Recv(ctx context.Context) (Notification, error) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			n := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			return n, nil
+		}
+		closed := s.closed
+		s.mu.Unlock()
+
+		if closed {
+			return Notification{}, errors.New(`kodi_jsonrpc: subscription closed`)
+		}
+
+		select {
+		case <-s.signal:
+		case <-ctx.Done():
+			return Notification{}, ctx.Err()
+		}
+	}
+}
+
+// Len returns the number of Notifications currently queued and not yet
+// received, so a consumer can detect it is falling behind.
+func (s *Subscription) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Unsubscribe stops s from receiving further Notifications and wakes any
+// blocked Recv with an error. Safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.conn.subscriptionLock.Lock()
+	subs := s.conn.subscriptions[s.pattern]
+	for i, sub := range subs {
+		if sub == s {
+			s.conn.subscriptions[s.pattern] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	s.conn.subscriptionLock.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.signal)
+}
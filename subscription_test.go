@@ -0,0 +1,102 @@
+package kodi_jsonrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	cases := []struct {
+		pattern, method string
+		want            bool
+	}{
+		{`*`, `Player.OnPlay`, true},
+		{`Player.*`, `Player.OnPlay`, true},
+		{`Player.*`, `Application.OnVolumeChanged`, false},
+		{`Player.OnPlay`, `Player.OnPlay`, true},
+		{`Player.OnPlay`, `Player.OnStop`, false},
+		{`Player`, `Player.OnPlay`, false},
+	}
+
+	for _, c := range cases {
+		if got := subscriptionMatches(c.pattern, c.method); got != c.want {
+			t.Errorf(`subscriptionMatches(%q, %q) = %v, want %v`, c.pattern, c.method, got, c.want)
+		}
+	}
+}
+
+func TestSubscriptionRecv(t *testing.T) {
+	conn := &Connection{subscriptions: make(map[string][]*Subscription)}
+	sub := conn.Subscribe(`Player.OnPlay`)
+
+	n := Notification{Method: `Player.OnPlay`}
+	conn.dispatchSubscriptions(n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	got, err := sub.Recv(ctx)
+	if err != nil {
+		t.Fatalf(`Recv() error = %v`, err)
+	}
+	if got.Method != n.Method {
+		t.Errorf(`Recv() = %+v, want %+v`, got, n)
+	}
+
+	if sub.Len() != 0 {
+		t.Errorf(`Len() = %d, want 0`, sub.Len())
+	}
+}
+
+func TestSubscriptionRecvCtxCancel(t *testing.T) {
+	conn := &Connection{subscriptions: make(map[string][]*Subscription)}
+	sub := conn.Subscribe(`*`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := sub.Recv(ctx); err == nil {
+		t.Fatal(`Recv() with cancelled ctx returned nil error`)
+	}
+}
+
+func TestSubscriptionUnsubscribe(t *testing.T) {
+	conn := &Connection{subscriptions: make(map[string][]*Subscription)}
+	sub := conn.Subscribe(`Player.OnPlay`)
+
+	sub.Unsubscribe()
+
+	if len(conn.subscriptions[`Player.OnPlay`]) != 0 {
+		t.Errorf(`subscriptions[%q] still has %d entries after Unsubscribe`, `Player.OnPlay`, len(conn.subscriptions[`Player.OnPlay`]))
+	}
+
+	if _, err := sub.Recv(context.Background()); err == nil {
+		t.Fatal(`Recv() after Unsubscribe returned nil error`)
+	}
+
+	// Unsubscribe must be safe to call twice.
+	sub.Unsubscribe()
+}
+
+func TestCloseUnsubscribesSubscriptions(t *testing.T) {
+	c := newTestConnection()
+	sub := c.Subscribe(`Player.OnPlay`)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sub.Recv(context.Background())
+		done <- err
+	}()
+
+	c.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal(`Recv() after Close() returned nil error`)
+		}
+	case <-time.After(time.Second):
+		t.Fatal(`Recv() still blocked after Close()`)
+	}
+}
@@ -0,0 +1,147 @@
+package kodi_jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Transport abstracts the underlying wire connection to a Kodi JSON-RPC
+// server, so Connection does not need to know whether it is talking over
+// the raw TCP socket or a WebSocket endpoint.
+//
+// Implementations must be safe for one concurrent Send and one concurrent
+// Recv, but need not support concurrent Sends with each other.
+type Transport interface {
+	// Send writes a single JSON-RPC frame (request, notification, or batch)
+	// to the server.
+	Send(frame []byte) error
+
+	// Recv blocks until a single JSON-RPC frame (response or notification)
+	// has been read from the server.
+	Recv() ([]byte, error)
+
+	// Close shuts down the underlying connection.
+	Close() error
+}
+
+// DialTransport connects to address and returns a Transport appropriate for
+// its scheme: `tcp://host:port` (or a bare `host:port`, for backward
+// compatibility) dials the raw TCP socket, `ws://` and `wss://` dial the
+// WebSocket endpoint.
+func DialTransport(address string) (Transport, error) {
+	scheme, rest := splitScheme(address)
+	switch scheme {
+	case ``, `tcp`:
+		t, err := DialTCP(rest)
+		// t must not be returned directly: a nil *TCPTransport assigned to
+		// the Transport return value is a non-nil interface (it carries a
+		// type), so callers checking `transport == nil` would miss the
+		// failure.
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	case `ws`, `wss`:
+		t, err := DialWebSocket(address)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf(`kodi_jsonrpc: unsupported transport scheme %q`, scheme)
+	}
+}
+
+// splitScheme splits an address of the form `scheme://rest` into its scheme
+// and remainder. Addresses without a `://` are treated as having no scheme,
+// so plain `host:port` keeps working as it always has.
+func splitScheme(address string) (scheme, rest string) {
+	if i := strings.Index(address, `://`); i >= 0 {
+		return address[:i], address[i+len(`://`):]
+	}
+	return ``, address
+}
+
+// TCPTransport speaks JSON-RPC 2.0 over the raw Kodi TCP socket (port 9090).
+type TCPTransport struct {
+	conn net.Conn
+	dec  *json.Decoder
+}
+
+// DialTCP connects to address (`host:port`) over TCP.
+func DialTCP(address string) (*TCPTransport, error) {
+	conn, err := net.Dial(`tcp`, address)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPTransport{conn: conn, dec: json.NewDecoder(conn)}, nil
+}
+
+// Send implements Transport.
+func (t *TCPTransport) Send(frame []byte) error {
+	_, err := t.conn.Write(frame)
+	return err
+}
+
+// Recv implements Transport.
+//
+// Kodi writes consecutive JSON values back to back on the socket with no
+// delimiter, so a streaming decoder is used to find the boundary of the next
+// one.
+func (t *TCPTransport) Recv() ([]byte, error) {
+	var raw json.RawMessage
+	if err := t.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// Close implements Transport.
+func (t *TCPTransport) Close() error {
+	return t.conn.Close()
+}
+
+// WebSocketTransport speaks JSON-RPC 2.0 over Kodi's WebSocket endpoint
+// (ws://host:9090/jsonrpc, or the HTTP port when WebSockets are enabled in
+// Kodi's settings), framing each request/response as a single text message.
+type WebSocketTransport struct {
+	ws *websocket.Conn
+}
+
+// DialWebSocket connects to address (`ws://host:port/jsonrpc` or
+// `wss://host:port/jsonrpc`).
+func DialWebSocket(address string) (*WebSocketTransport, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &WebSocketTransport{ws: ws}, nil
+}
+
+// Send implements Transport.
+func (t *WebSocketTransport) Send(frame []byte) error {
+	return t.ws.WriteMessage(websocket.TextMessage, frame)
+}
+
+// Recv implements Transport.
+func (t *WebSocketTransport) Recv() ([]byte, error) {
+	_, data, err := t.ws.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Close implements Transport.
+func (t *WebSocketTransport) Close() error {
+	return t.ws.Close()
+}
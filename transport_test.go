@@ -0,0 +1,54 @@
+package kodi_jsonrpc
+
+import "testing"
+
+func TestSplitScheme(t *testing.T) {
+	cases := []struct {
+		address, wantScheme, wantRest string
+	}{
+		{`tcp://localhost:9090`, `tcp`, `localhost:9090`},
+		{`localhost:9090`, ``, `localhost:9090`},
+		{`ws://localhost:9090/jsonrpc`, `ws`, `localhost:9090/jsonrpc`},
+		{`wss://localhost:9090/jsonrpc`, `wss`, `localhost:9090/jsonrpc`},
+	}
+
+	for _, c := range cases {
+		scheme, rest := splitScheme(c.address)
+		if scheme != c.wantScheme || rest != c.wantRest {
+			t.Errorf(`splitScheme(%q) = (%q, %q), want (%q, %q)`, c.address, scheme, rest, c.wantScheme, c.wantRest)
+		}
+	}
+}
+
+func TestDialTransportUnknownScheme(t *testing.T) {
+	transport, err := DialTransport(`http://localhost:9090`)
+	if err == nil {
+		t.Fatal(`DialTransport() with unknown scheme returned nil error`)
+	}
+	if transport != nil {
+		t.Fatalf(`DialTransport() = %#v, want nil`, transport)
+	}
+}
+
+// TestDialTransportFailureReturnsGenuineNil is a regression test for the
+// typed-nil-interface bug fixed alongside this test: DialTCP/DialWebSocket
+// return a typed nil (*TCPTransport)(nil)/(*WebSocketTransport)(nil) on
+// failure, and DialTransport must not forward that through its Transport
+// return - doing so makes `transport != nil` true even though dialing
+// failed, since an interface holding a nil pointer is itself non-nil.
+func TestDialTransportFailureReturnsGenuineNil(t *testing.T) {
+	// Port 1 is reserved/unassigned, so the connection is refused immediately
+	// instead of timing out.
+	cases := []string{`tcp://127.0.0.1:1`, `127.0.0.1:1`, `ws://127.0.0.1:1/jsonrpc`}
+
+	for _, address := range cases {
+		transport, err := DialTransport(address)
+		if err == nil {
+			t.Errorf(`DialTransport(%q) returned nil error`, address)
+			continue
+		}
+		if transport != nil {
+			t.Errorf(`DialTransport(%q) = %#v, want nil Transport`, address, transport)
+		}
+	}
+}